@@ -19,6 +19,11 @@ var (
 	ErrIncompatibleVersion = errors.New("argon: incompatible version of argon2")
 	// ErrUnsupportedMode is returned when the mode is not supported.
 	ErrUnsupportedMode = errors.New("argon: unsupported argon2 mode")
+	// ErrArgon2dNotAllowed is returned by Verify when the stored hash uses
+	// Argon2d and the caller has not explicitly opted in via AllowArgon2d.
+	// Argon2d is data-dependent and therefore unsafe against side-channel
+	// attacks in most interactive password-verification scenarios.
+	ErrArgon2dNotAllowed = errors.New("argon: argon2d hash rejected; pass AllowArgon2d() to accept it")
 )
 
 const (
@@ -26,9 +31,54 @@ const (
 	ModeArgon2id = "argon2id"
 	// ModeArgon2i is optimized to resist side-channel attacks.
 	ModeArgon2i = "argon2i"
+	// ModeArgon2d is optimized to resist GPU cracking attacks, but is
+	// data-dependent and therefore unsafe against side-channel attacks.
+	ModeArgon2d = "argon2d"
 )
 
-// Params describes the input parameters used by the Argon2id algorithm.
+// Variant identifies which of the three Argon2 variants to use. It is the
+// typed counterpart to the Mode string and is the preferred way to select
+// a variant going forward; Mode is kept for backward compatibility.
+type Variant uint8
+
+const (
+	// Argon2id is the default variant, recommended by NIST for most
+	// password hashing use cases. It is the zero value of Variant.
+	Argon2id Variant = iota
+	// Argon2i is optimized to resist side-channel attacks.
+	Argon2i
+	// Argon2d is optimized to resist GPU cracking attacks, but is
+	// data-dependent and therefore unsafe against side-channel attacks.
+	Argon2d
+)
+
+// String returns the PHC mode identifier for v (e.g. "argon2id").
+func (v Variant) String() string {
+	switch v {
+	case Argon2i:
+		return ModeArgon2i
+	case Argon2d:
+		return ModeArgon2d
+	default:
+		return ModeArgon2id
+	}
+}
+
+// variantFromMode maps a PHC mode identifier to its typed Variant.
+func variantFromMode(mode string) (Variant, error) {
+	switch mode {
+	case ModeArgon2id, "":
+		return Argon2id, nil
+	case ModeArgon2i:
+		return Argon2i, nil
+	case ModeArgon2d:
+		return Argon2d, nil
+	default:
+		return 0, ErrUnsupportedMode
+	}
+}
+
+// Params describes the input parameters used by the Argon2 algorithm.
 // The params are set to satisfy NIST recommendations for password hashing.
 type Params struct {
 	// The amount of memory used by the algorithm (in kibibytes).
@@ -41,8 +91,36 @@ type Params struct {
 	SaltLength uint32
 	// The length of the generated key (or password hash). 32 bytes or more is recommended.
 	KeyLength uint32
-	// The mode of Argon2 to use (argon2id or argon2i).
+	// The mode of Argon2 to use (argon2id, argon2i or argon2d). Deprecated:
+	// prefer Variant. If both are set, Mode takes precedence for backward
+	// compatibility with existing callers.
 	Mode string
+	// Variant selects which Argon2 variant to use. Ignored if Mode is set.
+	Variant Variant
+	// Pepper is an optional server-side secret used by KeyedHash/KeyedVerify
+	// to HMAC the password before Argon2 hashing, binding the stored hash
+	// to a secret that never touches the database. Ignored by Hash/Verify.
+	Pepper []byte
+	// PepperID identifies which pepper (in a rotating set) was used to
+	// produce the hash. It is encoded in the PHC string as k=<id> so
+	// KeyedVerify can pick the matching secret out of the peppers it is
+	// given.
+	PepperID uint8
+	// AssociatedData is optional caller-supplied context (e.g. a user ID)
+	// folded into the Argon2 computation alongside the password via
+	// KeyedHash. It is not persisted in the PHC string, so verifying a
+	// hash produced with non-empty AssociatedData requires reproducing it
+	// out of band and passing it to KeyedVerify's associatedData parameter.
+	AssociatedData []byte
+}
+
+// variant resolves the effective Variant for p, honoring Mode over Variant
+// for backward compatibility.
+func (p *Params) variant() (Variant, error) {
+	if p.Mode != "" {
+		return variantFromMode(p.Mode)
+	}
+	return p.Variant, nil
 }
 
 const (
@@ -71,7 +149,8 @@ const (
 //     CPU cores. If higher parallelism is desired, set the Parallelism field manually.
 //   - SaltLength: 16 bytes
 //   - KeyLength: 32 bytes
-//   - Mode: argon2id
+//   - Variant: argon2id (Mode is left unset so that changing Variant on
+//     the returned *Params takes effect; see Params.variant)
 func DefaultParams() *Params {
 	p := uint8(runtime.NumCPU())
 	// Cap parallelism at 4 for defaults to avoid excessive resource usage on large machines for simple auth
@@ -85,7 +164,7 @@ func DefaultParams() *Params {
 		Parallelism: p,
 		SaltLength:  DefaultSaltLength,
 		KeyLength:   DefaultKeyLength,
-		Mode:        ModeArgon2id,
+		Variant:     Argon2id,
 	}
 }
 
@@ -102,58 +181,61 @@ func HashWithParams(password string, p *Params) (string, error) {
 		return "", err
 	}
 
-	var hash []byte
-
-	// Default to ModeArgon2id if Mode is not set for backward compatibility
-	mode := p.Mode
-	if mode == "" {
-		mode = ModeArgon2id
-	}
-
-	switch mode {
-	case ModeArgon2i:
-		hash = argon2.Key([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
-	case ModeArgon2id:
-		hash = argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
-	default:
-		return "", ErrUnsupportedMode
+	variant, err := p.variant()
+	if err != nil {
+		return "", err
 	}
+	hash := deriveKey(variant, []byte(password), salt, nil, nil, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
 
 	// Format: $argon2id$v=19$m=65536,t=1,p=4$salt$hash
 	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
 	b64Hash := base64.RawStdEncoding.EncodeToString(hash)
 
 	encoded := fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
-		mode, argon2.Version, p.Memory, p.Iterations, p.Parallelism, b64Salt, b64Hash)
+		variant, argon2.Version, p.Memory, p.Iterations, p.Parallelism, b64Salt, b64Hash)
 
 	return encoded, nil
 }
 
+// VerifyOption customizes the behavior of Verify.
+type VerifyOption func(*verifyOptions)
+
+type verifyOptions struct {
+	allowArgon2d bool
+}
+
+// AllowArgon2d permits Verify to accept hashes encoded with the Argon2d
+// variant. Argon2d is data-dependent and therefore unsafe against
+// side-channel attacks, so Verify rejects it unless the caller opts in
+// explicitly, typically because the hash is known not to be exposed to an
+// attacker capable of observing cache or timing side channels.
+func AllowArgon2d() VerifyOption {
+	return func(o *verifyOptions) { o.allowArgon2d = true }
+}
+
 // Verify compares a password against a hashed PHC string.
 // It returns true if the password matches, false otherwise.
-func Verify(password, encodedHash string) (bool, error) {
+func Verify(password, encodedHash string, opts ...VerifyOption) (bool, error) {
+	var o verifyOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	p, salt, hash, err := decodeHash(encodedHash)
 	if err != nil {
 		return false, err
 	}
 
-	var otherHash []byte
-
-	// Default to ModeArgon2id if Mode is not set for backward compatibility
-	mode := p.Mode
-	if mode == "" {
-		mode = ModeArgon2id
+	variant, err := p.variant()
+	if err != nil {
+		return false, err
 	}
-
-	switch mode {
-	case ModeArgon2i:
-		otherHash = argon2.Key([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
-	case ModeArgon2id:
-		otherHash = argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
-	default:
-		return false, ErrUnsupportedMode
+	if variant == Argon2d && !o.allowArgon2d {
+		return false, ErrArgon2dNotAllowed
 	}
 
+	otherHash := deriveKey(variant, []byte(password), salt, nil, nil, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
 	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
 		return true, nil
 	}
@@ -176,15 +258,47 @@ func decodeHash(encodedHash string) (p *Params, salt, hash []byte, err error) {
 	}
 
 	mode := vals[1]
-	if mode != ModeArgon2id && mode != ModeArgon2i {
-		return nil, nil, nil, ErrUnsupportedMode
+	variant, err := variantFromMode(mode)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
 	p = &Params{
-		Mode: mode,
+		Mode:    mode,
+		Variant: variant,
 	}
-	_, err = fmt.Sscanf(vals[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism)
-	if err != nil {
+
+	var haveMemory, haveIterations, haveParallelism bool
+	for _, field := range strings.Split(vals[3], ",") {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok {
+			return nil, nil, nil, ErrInvalidHash
+		}
+		switch key {
+		case "m":
+			if _, err = fmt.Sscanf(val, "%d", &p.Memory); err != nil {
+				return nil, nil, nil, ErrInvalidHash
+			}
+			haveMemory = true
+		case "t":
+			if _, err = fmt.Sscanf(val, "%d", &p.Iterations); err != nil {
+				return nil, nil, nil, ErrInvalidHash
+			}
+			haveIterations = true
+		case "p":
+			if _, err = fmt.Sscanf(val, "%d", &p.Parallelism); err != nil {
+				return nil, nil, nil, ErrInvalidHash
+			}
+			haveParallelism = true
+		case "k":
+			if _, err = fmt.Sscanf(val, "%d", &p.PepperID); err != nil {
+				return nil, nil, nil, ErrInvalidHash
+			}
+		default:
+			return nil, nil, nil, ErrInvalidHash
+		}
+	}
+	if !haveMemory || !haveIterations || !haveParallelism {
 		return nil, nil, nil, ErrInvalidHash
 	}
 
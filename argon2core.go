@@ -0,0 +1,393 @@
+package argon
+
+import (
+	"encoding/binary"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/blake2b"
+)
+
+// This file implements the Argon2 algorithm (RFC 9106) directly against
+// golang.org/x/crypto/blake2b, a public, independently-versioned package.
+// golang.org/x/crypto/argon2 only exports Key (Argon2i) and IDKey
+// (Argon2id); it has no public entry point for Argon2d, nor for its own
+// "secret"/"associated data" inputs. An earlier version of this file
+// reached those via go:linkname into argon2's unexported deriveKey, but
+// that symbol carries no compatibility guarantee and could be renamed,
+// inlined, or resignatured by any point release. This implementation
+// depends only on blake2b's stable public API instead.
+//
+// The well-exercised public Key/IDKey functions are still used whenever
+// they apply (see deriveKey in argon2d.go) — this core is only exercised
+// for Argon2d, and whenever associated data is supplied (KeyedHash,
+// KeyedVerify, and Hasher with Params.AssociatedData set).
+
+// argon2 "type" identifiers per RFC 9106 Section 3.1.
+const (
+	argon2TypeD  = 0
+	argon2TypeI  = 1
+	argon2TypeID = 2
+)
+
+const syncPoints = 4
+
+// block is one 1024-byte Argon2 memory block, viewed as 128 little-endian
+// 64-bit words.
+type block [128]uint64
+
+// argon2Core derives an Argon2 key of length keyLen for the given type,
+// following RFC 9106 end to end: H0 initialization, the memory matrix
+// fill (with data-dependent or data-independent addressing depending on
+// typ and, for Argon2id, the pass/slice), and final extraction.
+func argon2Core(typ int, password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	lanes := int(threads)
+
+	memoryBlocks := memory
+	if min := uint32(2 * syncPoints * lanes); memoryBlocks < min {
+		memoryBlocks = min
+	}
+	segmentLength := int(memoryBlocks) / (lanes * syncPoints)
+	q := segmentLength * syncPoints // blocks per lane
+	memoryBlocks = uint32(q * lanes)
+
+	// H0 is seeded with the caller's original memory cost, not the
+	// rounded-down memoryBlocks computed above: per RFC 9106 Section 3.2
+	// (and matching golang.org/x/crypto/argon2's own deriveKey, which
+	// calls initHash before rounding m' down to a multiple of 4*lanes),
+	// the memory parameter folded into H0 is the one the caller passed
+	// in. Seeding H0 with memoryBlocks instead produces a hash that is
+	// internally self-consistent but is not a standards-compliant Argon2
+	// hash whenever memory isn't already a multiple of 4*threads.
+	h0 := initialHash(password, salt, secret, data, time, memory, threads, keyLen, typ)
+
+	blocks := make([]block, lanes*q)
+	for lane := 0; lane < lanes; lane++ {
+		blocks[lane*q+0] = initialBlock(h0, 0, lane)
+		blocks[lane*q+1] = initialBlock(h0, 1, lane)
+	}
+
+	fillBlocks(blocks, typ, int(time), lanes, segmentLength, memoryBlocks)
+
+	var final block
+	for lane := 0; lane < lanes; lane++ {
+		last := blocks[lane*q+q-1]
+		for i := range final {
+			final[i] ^= last[i]
+		}
+	}
+
+	out := make([]byte, keyLen)
+	hPrime(out, blockBytes(final))
+	return out
+}
+
+// fillBlocks runs all passes of the memory fill step over blocks, which
+// holds lanes lanes of q = segmentLength*syncPoints blocks each.
+func fillBlocks(blocks []block, typ, passes, lanes, segmentLength int, memoryBlocks uint32) {
+	q := segmentLength * syncPoints
+
+	for pass := 0; pass < passes; pass++ {
+		for slice := 0; slice < syncPoints; slice++ {
+			independent := dataIndependent(typ, pass, slice)
+
+			for lane := 0; lane < lanes; lane++ {
+				var addr block
+				var addrCounter uint64
+
+				for idx := 0; idx < segmentLength; idx++ {
+					col := slice*segmentLength + idx
+
+					var j1, j2 uint32
+					if independent {
+						if idx%128 == 0 {
+							addrCounter++
+							addr = addressBlock(typ, uint64(pass), uint64(lane), uint64(slice), uint64(memoryBlocks), uint64(passes), addrCounter)
+						}
+						word := addr[idx%128]
+						j1, j2 = uint32(word), uint32(word>>32)
+					}
+
+					if pass == 0 && slice == 0 && col < 2 {
+						continue
+					}
+
+					prevCol := col - 1
+					if prevCol < 0 {
+						prevCol = q - 1
+					}
+					prev := blocks[lane*q+prevCol]
+
+					if !independent {
+						word := prev[0]
+						j1, j2 = uint32(word), uint32(word>>32)
+					}
+
+					refLane := lane
+					if !(pass == 0 && slice == 0) {
+						refLane = int(j2) % lanes
+					}
+
+					w := referenceAreaSize(pass, slice, idx, segmentLength, q, refLane == lane)
+
+					x := (uint64(j1) * uint64(j1)) >> 32
+					y := (uint64(w) * x) >> 32
+					zz := uint64(w) - 1 - y
+
+					start := 0
+					if pass != 0 && slice != syncPoints-1 {
+						start = (slice + 1) * segmentLength
+					}
+					refIndex := (start + int(zz)) % q
+
+					ref := blocks[refLane*q+refIndex]
+
+					var next block
+					compress(&next, &prev, &ref)
+					if pass > 0 {
+						old := blocks[lane*q+col]
+						for i := range next {
+							next[i] ^= old[i]
+						}
+					}
+					blocks[lane*q+col] = next
+				}
+			}
+		}
+	}
+}
+
+// referenceAreaSize computes W, the number of candidate blocks a
+// reference index may land on, per RFC 9106 Section 3.4.1.2.
+func referenceAreaSize(pass, slice, idx, segmentLength, laneLength int, sameLane bool) int {
+	if pass == 0 {
+		if slice == 0 {
+			return slice*segmentLength + idx - 1
+		}
+		if sameLane {
+			return slice*segmentLength + idx - 1
+		}
+		if idx == 0 {
+			return slice*segmentLength - 1
+		}
+		return slice * segmentLength
+	}
+	if sameLane {
+		return laneLength - segmentLength + idx - 1
+	}
+	if idx == 0 {
+		return laneLength - segmentLength - 1
+	}
+	return laneLength - segmentLength
+}
+
+// dataIndependent reports whether the given (pass, slice) uses Argon2i's
+// counter-based pseudo-random addressing rather than Argon2d's
+// data-dependent addressing. Argon2i always uses it; Argon2d never does;
+// Argon2id uses it only for the first half of the first pass.
+func dataIndependent(typ, pass, slice int) bool {
+	switch typ {
+	case argon2TypeI:
+		return true
+	case argon2TypeID:
+		return pass == 0 && slice < syncPoints/2
+	default:
+		return false
+	}
+}
+
+// addressBlock generates the next block of 128 pseudo-random addresses
+// used by data-independent addressing, per RFC 9106 Section 3.4.1.1.
+func addressBlock(typ int, pass, lane, slice, memoryBlocks, passes, counter uint64) block {
+	var input block
+	input[0] = pass
+	input[1] = lane
+	input[2] = slice
+	input[3] = memoryBlocks
+	input[4] = passes
+	input[5] = uint64(typ)
+	input[6] = counter
+
+	var zero, tmp, addr block
+	compress(&tmp, &zero, &input)
+	compress(&addr, &zero, &tmp)
+	return addr
+}
+
+// fBlaMka is Argon2's modified BLAKE2b mixing primitive: ordinary addition
+// with an extra multiplicative term for additional nonlinearity.
+func fBlaMka(x, y uint64) uint64 {
+	const mask = 0xFFFFFFFF
+	xy := (x & mask) * (y & mask)
+	return x + y + 2*xy
+}
+
+func rotr64(x uint64, n uint) uint64 {
+	return (x >> n) | (x << (64 - n))
+}
+
+// gb is one quarter-round of the BlaMka permutation.
+func gb(a, b, c, d *uint64) {
+	*a = fBlaMka(*a, *b)
+	*d = rotr64(*d^*a, 32)
+	*c = fBlaMka(*c, *d)
+	*b = rotr64(*b^*c, 24)
+	*a = fBlaMka(*a, *b)
+	*d = rotr64(*d^*a, 16)
+	*c = fBlaMka(*c, *d)
+	*b = rotr64(*b^*c, 63)
+}
+
+// blamkaP is the permutation P applied to one row or column group of 16
+// words (8 column mixes followed by 4 diagonal mixes), matching BLAKE2b's
+// round function with fBlaMka in place of plain addition.
+func blamkaP(v *[16]uint64) {
+	gb(&v[0], &v[4], &v[8], &v[12])
+	gb(&v[1], &v[5], &v[9], &v[13])
+	gb(&v[2], &v[6], &v[10], &v[14])
+	gb(&v[3], &v[7], &v[11], &v[15])
+	gb(&v[0], &v[5], &v[10], &v[15])
+	gb(&v[1], &v[6], &v[11], &v[12])
+	gb(&v[2], &v[7], &v[8], &v[13])
+	gb(&v[3], &v[4], &v[9], &v[14])
+}
+
+// compress is Argon2's compression function G(x, y), applying blamkaP to
+// each of the 8 rows and then each of the 8 columns of the 8x8 matrix of
+// 16-byte registers formed by x xor y.
+func compress(out, x, y *block) {
+	var r block
+	for i := range r {
+		r[i] = x[i] ^ y[i]
+	}
+
+	q := r
+	for i := 0; i < 8; i++ {
+		var row [16]uint64
+		copy(row[:], q[i*16:i*16+16])
+		blamkaP(&row)
+		copy(q[i*16:i*16+16], row[:])
+	}
+	for i := 0; i < 8; i++ {
+		var col [16]uint64
+		for j := 0; j < 8; j++ {
+			col[2*j] = q[16*j+2*i]
+			col[2*j+1] = q[16*j+2*i+1]
+		}
+		blamkaP(&col)
+		for j := 0; j < 8; j++ {
+			q[16*j+2*i] = col[2*j]
+			q[16*j+2*i+1] = col[2*j+1]
+		}
+	}
+
+	for i := range out {
+		out[i] = r[i] ^ q[i]
+	}
+}
+
+// initialHash computes H0, the Blake2b-512 digest seeding the whole
+// derivation, per RFC 9106 Section 3.2.
+func initialHash(password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32, typ int) [64]byte {
+	h, _ := blake2b.New512(nil)
+
+	var buf [4]byte
+	writeUint32 := func(v uint32) {
+		binary.LittleEndian.PutUint32(buf[:], v)
+		h.Write(buf[:])
+	}
+	writeBytes := func(b []byte) {
+		writeUint32(uint32(len(b)))
+		h.Write(b)
+	}
+
+	writeUint32(uint32(threads))
+	writeUint32(keyLen)
+	writeUint32(memory)
+	writeUint32(time)
+	writeUint32(uint32(argon2.Version))
+	writeUint32(uint32(typ))
+	writeBytes(password)
+	writeBytes(salt)
+	writeBytes(secret)
+	writeBytes(data)
+
+	var out [64]byte
+	h.Sum(out[:0])
+	return out
+}
+
+// initialBlock derives one of a lane's two seed blocks via H'(1024, H0 ||
+// LE32(blockIndex) || LE32(lane)).
+func initialBlock(h0 [64]byte, blockIndex, lane int) block {
+	input := make([]byte, 0, len(h0)+8)
+	input = append(input, h0[:]...)
+
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(blockIndex))
+	input = append(input, buf[:]...)
+	binary.LittleEndian.PutUint32(buf[:], uint32(lane))
+	input = append(input, buf[:]...)
+
+	raw := make([]byte, 1024)
+	hPrime(raw, input)
+	return bytesToBlock(raw)
+}
+
+// hPrime is Argon2's variable-length hash function H', built from
+// Blake2b per RFC 9106 Section 3.2: a single call for outputs of 64 bytes
+// or less, otherwise a chain of Blake2b-512 digests each contributing 32
+// bytes, ending with one Blake2b digest sized to exactly the bytes that
+// remain.
+func hPrime(out, in []byte) {
+	outLen := len(out)
+	if outLen <= 64 {
+		h, _ := blake2b.New(outLen, nil)
+		var buf [4]byte
+		binary.LittleEndian.PutUint32(buf[:], uint32(outLen))
+		h.Write(buf[:])
+		h.Write(in)
+		h.Sum(out[:0])
+		return
+	}
+
+	h, _ := blake2b.New512(nil)
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], uint32(outLen))
+	h.Write(buf[:])
+	h.Write(in)
+	var v [64]byte
+	h.Sum(v[:0])
+
+	copy(out, v[:32])
+	remaining := out[32:]
+
+	for len(remaining) > 64 {
+		h.Reset()
+		h.Write(v[:])
+		var next [64]byte
+		h.Sum(next[:0])
+		copy(remaining, next[:32])
+		remaining = remaining[32:]
+		v = next
+	}
+
+	last, _ := blake2b.New(len(remaining), nil)
+	last.Write(v[:])
+	last.Sum(remaining[:0])
+}
+
+func bytesToBlock(b []byte) block {
+	var blk block
+	for i := range blk {
+		blk[i] = binary.LittleEndian.Uint64(b[i*8 : i*8+8])
+	}
+	return blk
+}
+
+func blockBytes(blk block) []byte {
+	b := make([]byte, 1024)
+	for i := range blk {
+		binary.LittleEndian.PutUint64(b[i*8:i*8+8], blk[i])
+	}
+	return b
+}
@@ -0,0 +1,105 @@
+package argon
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Known-answer tests from RFC 9106 Appendix A / the generate_test_vectors
+// suite shipped with the reference Argon2 implementation (the same vectors
+// golang.org/x/crypto/argon2's own tests check against). Regression test
+// for a bug where H0 was seeded with the memory cost rounded down to a
+// multiple of 4*threads instead of the caller's original value: that bug
+// only surfaces when memory isn't already such a multiple, which is why
+// threads=3 and threads=6 are included below alongside the power-of-two
+// cases.
+var (
+	katPassword = []byte{
+		0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01,
+		0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01,
+		0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01,
+		0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01, 0x01,
+	}
+	katSalt   = []byte{0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02, 0x02}
+	katSecret = []byte{0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03, 0x03}
+	katAAD    = []byte{0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04}
+)
+
+func TestArgon2CoreKATWithSecretAndAssociatedData(t *testing.T) {
+	cases := []struct {
+		name string
+		typ  int
+		want string
+	}{
+		{"Argon2d", argon2TypeD, "512b391b6f1162975371d30919734294f868e3be3984f3c1a13a4db9fabe4acb"},
+		{"Argon2i", argon2TypeI, "c814d9d1dc7f37aa13f0d77f2494bda1c8de6b016dd388d29952a4c4672b6ce8"},
+		{"Argon2id", argon2TypeID, "0d640df58d78766c08c037a34a8b53c9d01ef0452d75b65eb52520e96b01e659"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			want, err := hex.DecodeString(c.want)
+			if err != nil {
+				t.Fatalf("bad test vector: %v", err)
+			}
+			got := argon2Core(c.typ, katPassword, katSalt, katSecret, katAAD, 3, 32, 4, 32)
+			if !bytes.Equal(got, want) {
+				t.Errorf("%s+secret+AAD mismatch:\n got  %x\n want %x", c.name, got, want)
+			}
+		})
+	}
+}
+
+func TestArgon2dKATVectors(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesalt")
+	cases := []struct {
+		time, memory uint32
+		threads      uint8
+		hash         string
+	}{
+		{time: 1, memory: 64, threads: 1, hash: "8727405fd07c32c78d64f547f24150d3f2e703a89f981a19"},
+		{time: 2, memory: 64, threads: 1, hash: "3be9ec79a69b75d3752acb59a1fbb8b295a46529c48fbb75"},
+		{time: 2, memory: 64, threads: 2, hash: "68e2462c98b8bc6bb60ec68db418ae2c9ed24fc6748a40e9"},
+		{time: 3, memory: 256, threads: 2, hash: "f4f0669218eaf3641f39cc97efb915721102f4b128211ef2"},
+		{time: 4, memory: 4096, threads: 4, hash: "935598181aa8dc2b720914aa6435ac8d3e3a4210c5b0fb2d"},
+		{time: 4, memory: 1024, threads: 8, hash: "83604fc2ad0589b9d055578f4d3cc55bc616df3578a896e9"},
+		// Non-power-of-two thread counts: memory isn't an exact multiple of
+		// 4*threads, which is what the H0-rounding bug above required to
+		// surface.
+		{time: 2, memory: 64, threads: 3, hash: "22474a423bda2ccd36ec9afd5119e5c8949798cadf659f51"},
+		{time: 3, memory: 1024, threads: 6, hash: "a3351b0319a53229152023d9206902f4ef59661cdca89481"},
+	}
+	for i, c := range cases {
+		want, err := hex.DecodeString(c.hash)
+		if err != nil {
+			t.Fatalf("case %d: bad test vector: %v", i, err)
+		}
+		got := argon2Core(argon2TypeD, password, salt, nil, nil, c.time, c.memory, c.threads, uint32(len(want)))
+		if !bytes.Equal(got, want) {
+			t.Errorf("case %d (threads=%d): got %x want %x", i, c.threads, got, want)
+		}
+	}
+}
+
+// TestArgon2CoreMatchesUpstreamOddLanes cross-checks argon2Core against
+// golang.org/x/crypto/argon2's public Key/IDKey for every thread count
+// from 1 to 8, including those that don't evenly divide a power-of-two
+// memory cost.
+func TestArgon2CoreMatchesUpstreamOddLanes(t *testing.T) {
+	password, salt := []byte("password"), []byte("somesaltsomesalt")
+	for _, threads := range []uint8{1, 2, 3, 4, 5, 6, 7, 8} {
+		gotI := argon2Core(argon2TypeI, password, salt, nil, nil, 2, 1024, threads, 32)
+		wantI := argon2.Key(password, salt, 2, 1024, threads, 32)
+		if !bytes.Equal(gotI, wantI) {
+			t.Errorf("threads=%d argon2i mismatch:\n got  %x\n want %x", threads, gotI, wantI)
+		}
+
+		gotID := argon2Core(argon2TypeID, password, salt, nil, nil, 2, 1024, threads, 32)
+		wantID := argon2.IDKey(password, salt, 2, 1024, threads, 32)
+		if !bytes.Equal(gotID, wantID) {
+			t.Errorf("threads=%d argon2id mismatch:\n got  %x\n want %x", threads, gotID, wantID)
+		}
+	}
+}
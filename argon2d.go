@@ -0,0 +1,32 @@
+package argon
+
+import "golang.org/x/crypto/argon2"
+
+// deriveKey computes the Argon2 key for the given variant. secret and
+// data are Argon2's optional "key" and "associated data" inputs; data
+// carries Params.AssociatedData, while the pepper is applied by prehash
+// and every caller passes a nil secret. Where upstream's public Key/IDKey cover the case
+// (Argon2i/Argon2id with no secret or data), they are used directly,
+// since they are the most widely exercised path; Argon2d, and any use of
+// secret/data, go through this package's own RFC 9106 implementation in
+// argon2core.go, since golang.org/x/crypto/argon2 exposes no public entry
+// point for either.
+func deriveKey(variant Variant, password, salt, secret, data []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	if len(secret) == 0 && len(data) == 0 {
+		switch variant {
+		case Argon2i:
+			return argon2.Key(password, salt, time, memory, threads, keyLen)
+		case Argon2id:
+			return argon2.IDKey(password, salt, time, memory, threads, keyLen)
+		}
+	}
+
+	typ := argon2TypeID
+	switch variant {
+	case Argon2i:
+		typ = argon2TypeI
+	case Argon2d:
+		typ = argon2TypeD
+	}
+	return argon2Core(typ, password, salt, secret, data, time, memory, threads, keyLen)
+}
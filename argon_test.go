@@ -226,16 +226,91 @@ func TestDefaultParamsValues(t *testing.T) {
 	if p.KeyLength != 32 {
 		t.Errorf("Default KeyLength expected 32, got %d", p.KeyLength)
 	}
-	if p.Mode != ModeArgon2id {
-		t.Errorf("Default Mode expected argon2id, got %s", p.Mode)
+	if p.Variant != Argon2id {
+		t.Errorf("Default Variant expected Argon2id, got %v", p.Variant)
+	}
+	if p.Mode != "" {
+		t.Errorf("Default Mode expected to be left unset so Variant isn't shadowed, got %q", p.Mode)
+	}
+}
+
+func TestDefaultParamsVariantIsSettable(t *testing.T) {
+	// Regression test: DefaultParams used to set Mode explicitly, which
+	// took precedence over Variant and silently made changing Variant a
+	// no-op.
+	p := DefaultParams()
+	p.Variant = Argon2d
+
+	hash, err := HashWithParams("password", p)
+	if err != nil {
+		t.Fatalf("HashWithParams failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2d$") {
+		t.Errorf("Expected setting Variant on DefaultParams() to take effect, got %s", hash)
 	}
 }
 
 func TestVerifyUnsupportedMode(t *testing.T) {
-	// Hash with unsupported mode
-	unsupportedHash := "$argon2d$v=19$m=65536,t=1,p=4$dGVzdHNhbHQ$dGVzdGhhc2g"
+	// Hash with a genuinely unsupported mode
+	unsupportedHash := "$argon2x$v=19$m=65536,t=1,p=4$dGVzdHNhbHQ$dGVzdGhhc2g"
 	_, err := Verify("password", unsupportedHash)
 	if err != ErrUnsupportedMode {
 		t.Errorf("Expected ErrUnsupportedMode, got %v", err)
 	}
 }
+
+func TestHashAndVerifyArgon2d(t *testing.T) {
+	password := "password"
+	params := &Params{
+		Memory:      32 * 1024,
+		Iterations:  2,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Variant:     Argon2d,
+	}
+
+	hash, err := HashWithParams(password, params)
+	if err != nil {
+		t.Fatalf("HashWithParams (Argon2d) failed: %v", err)
+	}
+
+	if !strings.HasPrefix(hash, "$argon2d$") {
+		t.Errorf("Expected prefix $argon2d$, got %s", hash)
+	}
+
+	// Argon2d hashes are rejected by Verify unless explicitly allowed.
+	_, err = Verify(password, hash)
+	if err != ErrArgon2dNotAllowed {
+		t.Errorf("Expected ErrArgon2dNotAllowed, got %v", err)
+	}
+
+	match, err := Verify(password, hash, AllowArgon2d())
+	if err != nil {
+		t.Fatalf("Verify (Argon2d, allowed) failed: %v", err)
+	}
+	if !match {
+		t.Error("Verify (Argon2d, allowed) returned false for correct password")
+	}
+}
+
+func TestParamsModePrecedesOverVariant(t *testing.T) {
+	// Mode, when set, takes precedence over Variant for backward compatibility.
+	params := &Params{
+		Memory:      32 * 1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2i,
+		Variant:     Argon2id,
+	}
+
+	hash, err := HashWithParams("password", params)
+	if err != nil {
+		t.Fatalf("HashWithParams failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2i$") {
+		t.Errorf("Expected Mode to take precedence and produce $argon2i$, got %s", hash)
+	}
+}
@@ -0,0 +1,187 @@
+package argon
+
+import (
+	"errors"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// ErrCalibrationFailed is returned by CalibrateParams when even the
+// largest candidate within the configured memory and iteration bounds
+// (MaxMemory and MaxIterations) hashes faster than the target duration.
+var ErrCalibrationFailed = errors.New("argon: calibration failed to converge")
+
+// CalibrateOptions bounds the search performed by CalibrateParams.
+type CalibrateOptions struct {
+	// MinMemory is the starting memory (in kibibytes) to probe. Defaults
+	// to DefaultMemory (64 MB) if zero.
+	MinMemory uint32
+	// MaxMemory caps how far memory is allowed to grow (in kibibytes).
+	// Defaults to 1 GB if zero.
+	MaxMemory uint32
+	// MaxIterations caps how far Iterations is allowed to grow once
+	// MaxMemory has been reached. Defaults to 64 if zero.
+	MaxIterations uint32
+	// MaxParallelism caps Parallelism. Defaults to DefaultParallelism if
+	// zero. Parallelism itself defaults to runtime.NumCPU(), capped by
+	// this value.
+	MaxParallelism uint8
+	// SaltLength and KeyLength are carried through to the returned Params
+	// unmodified. They default to DefaultSaltLength and DefaultKeyLength.
+	SaltLength uint32
+	KeyLength  uint32
+	// Variant selects which Argon2 variant to calibrate for. Defaults to
+	// Argon2id.
+	Variant Variant
+}
+
+const (
+	calibrateDefaultMaxMemory     = 1024 * 1024 // 1 GB, in kibibytes
+	calibrateDefaultMaxIterations = 64
+	calibrateSamples              = 3
+)
+
+func (o CalibrateOptions) withDefaults() CalibrateOptions {
+	if o.MinMemory == 0 {
+		o.MinMemory = DefaultMemory
+	}
+	if o.MaxMemory == 0 {
+		o.MaxMemory = calibrateDefaultMaxMemory
+	}
+	if o.MaxIterations == 0 {
+		o.MaxIterations = calibrateDefaultMaxIterations
+	}
+	if o.MaxParallelism == 0 {
+		o.MaxParallelism = DefaultParallelism
+	}
+	if o.SaltLength == 0 {
+		o.SaltLength = DefaultSaltLength
+	}
+	if o.KeyLength == 0 {
+		o.KeyLength = DefaultKeyLength
+	}
+	return o
+}
+
+// CalibrateParams empirically tunes Argon2 parameters to hit a target
+// per-hash wall-clock budget on the current machine, e.g. 250ms for
+// interactive logins or 1s for KDF use. Parallelism is fixed up front to
+// runtime.NumCPU() (capped by opts.MaxParallelism); memory is then grown
+// geometrically from opts.MinMemory up to opts.MaxMemory until the target
+// is reached, and if memory saturates first, Iterations is grown instead
+// until opts.MaxIterations. Each candidate is measured calibrateSamples
+// times and the median is used, to reduce noise from scheduling jitter.
+//
+// The returned Params never fall below OWASP's minimums, even on very
+// fast hardware where the target duration would otherwise be reached with
+// a smaller memory cost.
+func CalibrateParams(target time.Duration, opts CalibrateOptions) (*Params, error) {
+	opts = opts.withDefaults()
+
+	parallelism := uint8(runtime.NumCPU())
+	if parallelism > opts.MaxParallelism {
+		parallelism = opts.MaxParallelism
+	}
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	p := &Params{
+		Memory:      opts.MinMemory,
+		Iterations:  1,
+		Parallelism: parallelism,
+		SaltLength:  opts.SaltLength,
+		KeyLength:   opts.KeyLength,
+		Variant:     opts.Variant,
+	}
+
+	// Warm up: the first hash on a cold machine can be slower than
+	// subsequent ones (page faults, CPU frequency scaling), which would
+	// bias the very first measurement.
+	if _, err := measureHash(p); err != nil {
+		return nil, err
+	}
+
+	// Grow memory geometrically until the target is reached or we saturate.
+	for {
+		d, err := medianHashDuration(p)
+		if err != nil {
+			return nil, err
+		}
+		if d >= target {
+			return clampToOWASPMinimums(p), nil
+		}
+		if p.Memory >= opts.MaxMemory {
+			break
+		}
+		next := p.Memory * 2
+		if next > opts.MaxMemory {
+			next = opts.MaxMemory
+		}
+		p.Memory = next
+	}
+
+	// Memory is saturated; grow Iterations instead.
+	for p.Iterations < opts.MaxIterations {
+		d, err := medianHashDuration(p)
+		if err != nil {
+			return nil, err
+		}
+		if d >= target {
+			return clampToOWASPMinimums(p), nil
+		}
+		p.Iterations++
+	}
+
+	d, err := medianHashDuration(p)
+	if err != nil {
+		return nil, err
+	}
+	if d < target {
+		return nil, ErrCalibrationFailed
+	}
+	return clampToOWASPMinimums(p), nil
+}
+
+func measureHash(p *Params) (time.Duration, error) {
+	start := time.Now()
+	if _, err := HashWithParams("argon-calibration-probe", p); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+func medianHashDuration(p *Params) (time.Duration, error) {
+	samples := make([]time.Duration, calibrateSamples)
+	for i := range samples {
+		d, err := measureHash(p)
+		if err != nil {
+			return 0, err
+		}
+		samples[i] = d
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)/2], nil
+}
+
+// clampToOWASPMinimums ensures p never falls below OWASP's recommended
+// floor, which calibration could otherwise undercut on very fast hardware.
+func clampToOWASPMinimums(p *Params) *Params {
+	if p.Memory < DefaultMemory {
+		p.Memory = DefaultMemory
+	}
+	if p.Iterations < DefaultIterations {
+		p.Iterations = DefaultIterations
+	}
+	if p.Parallelism < 1 {
+		p.Parallelism = 1
+	}
+	if p.SaltLength < DefaultSaltLength {
+		p.SaltLength = DefaultSaltLength
+	}
+	if p.KeyLength < DefaultKeyLength {
+		p.KeyLength = DefaultKeyLength
+	}
+	return p
+}
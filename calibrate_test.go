@@ -0,0 +1,49 @@
+package argon
+
+import "testing"
+
+func TestCalibrateParamsMeetsOWASPMinimums(t *testing.T) {
+	p, err := CalibrateParams(0, CalibrateOptions{
+		MinMemory:     8 * 1024,
+		MaxMemory:     16 * 1024,
+		MaxIterations: 2,
+	})
+	if err != nil {
+		t.Fatalf("CalibrateParams failed: %v", err)
+	}
+
+	if p.Memory < DefaultMemory {
+		t.Errorf("Memory %d is below the OWASP minimum %d", p.Memory, DefaultMemory)
+	}
+	if p.Iterations < DefaultIterations {
+		t.Errorf("Iterations %d is below the minimum %d", p.Iterations, DefaultIterations)
+	}
+	if p.Parallelism < 1 {
+		t.Errorf("Parallelism %d should be at least 1", p.Parallelism)
+	}
+	if p.SaltLength < DefaultSaltLength {
+		t.Errorf("SaltLength %d is below the minimum %d", p.SaltLength, DefaultSaltLength)
+	}
+	if p.KeyLength < DefaultKeyLength {
+		t.Errorf("KeyLength %d is below the minimum %d", p.KeyLength, DefaultKeyLength)
+	}
+
+	if _, err := HashWithParams("password", p); err != nil {
+		t.Errorf("calibrated params failed to produce a hash: %v", err)
+	}
+}
+
+func TestCalibrateParamsRespectsMaxParallelism(t *testing.T) {
+	p, err := CalibrateParams(0, CalibrateOptions{
+		MinMemory:      8 * 1024,
+		MaxMemory:      16 * 1024,
+		MaxIterations:  1,
+		MaxParallelism: 1,
+	})
+	if err != nil {
+		t.Fatalf("CalibrateParams failed: %v", err)
+	}
+	if p.Parallelism != 1 {
+		t.Errorf("Expected Parallelism capped at 1, got %d", p.Parallelism)
+	}
+}
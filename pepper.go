@@ -0,0 +1,113 @@
+package argon
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+var (
+	// ErrPepperRequired is returned by KeyedHash when p.Pepper is empty.
+	ErrPepperRequired = errors.New("argon: pepper is required for KeyedHash")
+	// ErrPepperNotFound is returned by KeyedVerify when the hash's k=<id>
+	// field does not index into the peppers supplied by the caller.
+	ErrPepperNotFound = errors.New("argon: no pepper supplied for the hash's key id")
+)
+
+// prehash binds pepper into password via HMAC-SHA256 before it reaches
+// Argon2, the standard "pre-hash pepper" construction: unlike passing the
+// pepper as Argon2's own secret input, this keeps the server-side secret
+// out of the (comparatively slow, attacker-tunable) Argon2 parameters
+// entirely and lets it be rotated independently of any stored hash.
+func prehash(password string, pepper []byte) []byte {
+	mac := hmac.New(sha256.New, pepper)
+	mac.Write([]byte(password))
+	return mac.Sum(nil)
+}
+
+// KeyedHash is like HashWithParams but additionally HMACs password with
+// p.Pepper before Argon2 hashing, binding the resulting hash to a secret
+// held outside the database. p.PepperID is encoded into the PHC string as
+// k=<id> so that KeyedVerify can later select the matching secret out of
+// a rotating set of peppers.
+//
+// p.AssociatedData, if set, is not persisted in the PHC string: pass the
+// identical value to KeyedVerify's associatedData parameter, or
+// verification will never succeed.
+func KeyedHash(password string, p *Params) (string, error) {
+	if len(p.Pepper) == 0 {
+		return "", ErrPepperRequired
+	}
+
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	variant, err := p.variant()
+	if err != nil {
+		return "", err
+	}
+
+	keyed := prehash(password, p.Pepper)
+	hash := deriveKey(variant, keyed, salt, nil, p.AssociatedData, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
+	b64Hash := base64.RawStdEncoding.EncodeToString(hash)
+
+	encoded := fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d,k=%d$%s$%s",
+		variant, argon2.Version, p.Memory, p.Iterations, p.Parallelism, p.PepperID, b64Salt, b64Hash)
+
+	return encoded, nil
+}
+
+// KeyedVerify is like Verify but additionally HMACs password with the
+// pepper selected by the hash's k=<id> field (indexing into peppers, in
+// the order given) before comparison. Pass every pepper still considered
+// valid, e.g. the current one plus any being phased out during rotation.
+// Hashes without a k=<id> field (produced before pepper support, or by
+// plain Hash/HashWithParams) are treated as key id 0.
+//
+// associatedData must equal whatever Params.AssociatedData was set to
+// when the hash was created via KeyedHash (nil if it was unset); it is
+// not recoverable from the PHC string itself.
+//
+// Like Verify, KeyedVerify rejects Argon2d-variant hashes unless the
+// caller opts in via AllowArgon2d, since Argon2d is unsafe against
+// side-channel attacks in most interactive verification scenarios.
+func KeyedVerify(password, encodedHash string, associatedData []byte, peppers [][]byte, opts ...VerifyOption) (bool, error) {
+	var o verifyOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	p, salt, hash, err := decodeHash(encodedHash)
+	if err != nil {
+		return false, err
+	}
+	if int(p.PepperID) >= len(peppers) {
+		return false, ErrPepperNotFound
+	}
+
+	variant, err := p.variant()
+	if err != nil {
+		return false, err
+	}
+	if variant == Argon2d && !o.allowArgon2d {
+		return false, ErrArgon2dNotAllowed
+	}
+
+	keyed := prehash(password, peppers[p.PepperID])
+	otherHash := deriveKey(variant, keyed, salt, nil, associatedData, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
+		return true, nil
+	}
+	return false, nil
+}
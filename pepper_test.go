@@ -0,0 +1,202 @@
+package argon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyedHashAndVerify(t *testing.T) {
+	password := "correct_horse_battery_staple"
+	pepper := []byte("server-side-secret")
+	params := &Params{
+		Memory:      32 * 1024,
+		Iterations:  1,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+		Pepper:      pepper,
+		PepperID:    1,
+	}
+
+	hash, err := KeyedHash(password, params)
+	if err != nil {
+		t.Fatalf("KeyedHash failed: %v", err)
+	}
+
+	if !strings.Contains(hash, ",k=1$") {
+		t.Errorf("Expected hash to encode k=1, got %s", hash)
+	}
+
+	peppers := [][]byte{nil, pepper}
+	match, err := KeyedVerify(password, hash, nil, peppers)
+	if err != nil {
+		t.Fatalf("KeyedVerify failed: %v", err)
+	}
+	if !match {
+		t.Error("KeyedVerify returned false for correct password and pepper")
+	}
+
+	match, err = KeyedVerify("wrong_password", hash, nil, peppers)
+	if err != nil {
+		t.Fatalf("KeyedVerify failed: %v", err)
+	}
+	if match {
+		t.Error("KeyedVerify returned true for incorrect password")
+	}
+}
+
+func TestKeyedHashAndVerifyWithAssociatedData(t *testing.T) {
+	password := "correct_horse_battery_staple"
+	pepper := []byte("server-side-secret")
+	associatedData := []byte("user-id-42")
+
+	hash, err := KeyedHash(password, &Params{
+		Memory: 32 * 1024, Iterations: 1, Parallelism: 2,
+		SaltLength: 16, KeyLength: 32, Mode: ModeArgon2id,
+		Pepper: pepper, AssociatedData: associatedData,
+	})
+	if err != nil {
+		t.Fatalf("KeyedHash failed: %v", err)
+	}
+
+	peppers := [][]byte{pepper}
+
+	match, err := KeyedVerify(password, hash, associatedData, peppers)
+	if err != nil {
+		t.Fatalf("KeyedVerify failed: %v", err)
+	}
+	if !match {
+		t.Error("KeyedVerify returned false when given the associated data used at hash time")
+	}
+
+	match, err = KeyedVerify(password, hash, []byte("wrong-user-id"), peppers)
+	if err != nil {
+		t.Fatalf("KeyedVerify failed: %v", err)
+	}
+	if match {
+		t.Error("KeyedVerify returned true despite mismatched associated data")
+	}
+}
+
+func TestKeyedHashAndVerifyOddParallelismWithAssociatedData(t *testing.T) {
+	// Round-trips the argon2Core path (taken because AssociatedData is set)
+	// with a memory cost that isn't a multiple of 4*Parallelism (64 with
+	// Parallelism 3). Standards compliance of that case is checked against
+	// known-answer vectors in argon2core_test.go; this test only checks
+	// that KeyedHash and KeyedVerify agree with each other.
+	password := "correct_horse_battery_staple"
+	pepper := []byte("server-side-secret")
+	associatedData := []byte("user-id-42")
+
+	hash, err := KeyedHash(password, &Params{
+		Memory: 64, Iterations: 2, Parallelism: 3,
+		SaltLength: 16, KeyLength: 32, Mode: ModeArgon2id,
+		Pepper: pepper, AssociatedData: associatedData,
+	})
+	if err != nil {
+		t.Fatalf("KeyedHash failed: %v", err)
+	}
+
+	match, err := KeyedVerify(password, hash, associatedData, [][]byte{pepper})
+	if err != nil {
+		t.Fatalf("KeyedVerify failed: %v", err)
+	}
+	if !match {
+		t.Error("KeyedVerify returned false for Parallelism=3 with associated data")
+	}
+}
+
+func TestKeyedVerifyArgon2dRequiresOptIn(t *testing.T) {
+	password := "password"
+	pepper := []byte("server-side-secret")
+
+	hash, err := KeyedHash(password, &Params{
+		Memory: 32 * 1024, Iterations: 1, Parallelism: 2,
+		SaltLength: 16, KeyLength: 32, Variant: Argon2d,
+		Pepper: pepper,
+	})
+	if err != nil {
+		t.Fatalf("KeyedHash failed: %v", err)
+	}
+
+	peppers := [][]byte{pepper}
+
+	_, err = KeyedVerify(password, hash, nil, peppers)
+	if err != ErrArgon2dNotAllowed {
+		t.Errorf("Expected ErrArgon2dNotAllowed, got %v", err)
+	}
+
+	match, err := KeyedVerify(password, hash, nil, peppers, AllowArgon2d())
+	if err != nil {
+		t.Fatalf("KeyedVerify (allowed) failed: %v", err)
+	}
+	if !match {
+		t.Error("KeyedVerify (allowed) returned false for correct password and pepper")
+	}
+}
+
+func TestKeyedHashRequiresPepper(t *testing.T) {
+	_, err := KeyedHash("password", DefaultParams())
+	if err != ErrPepperRequired {
+		t.Errorf("Expected ErrPepperRequired, got %v", err)
+	}
+}
+
+func TestKeyedVerifyMissingPepper(t *testing.T) {
+	params := &Params{
+		Memory:      32 * 1024,
+		Iterations:  1,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+		Pepper:      []byte("secret"),
+		PepperID:    2,
+	}
+
+	hash, err := KeyedHash("password", params)
+	if err != nil {
+		t.Fatalf("KeyedHash failed: %v", err)
+	}
+
+	_, err = KeyedVerify("password", hash, nil, [][]byte{[]byte("only-one-pepper")})
+	if err != ErrPepperNotFound {
+		t.Errorf("Expected ErrPepperNotFound, got %v", err)
+	}
+}
+
+func TestKeyedVerifyRotation(t *testing.T) {
+	oldPepper := []byte("old-secret")
+	newPepper := []byte("new-secret")
+	password := "password"
+
+	oldHash, err := KeyedHash(password, &Params{
+		Memory: 32 * 1024, Iterations: 1, Parallelism: 2,
+		SaltLength: 16, KeyLength: 32, Mode: ModeArgon2id,
+		Pepper: oldPepper, PepperID: 0,
+	})
+	if err != nil {
+		t.Fatalf("KeyedHash failed: %v", err)
+	}
+
+	newHash, err := KeyedHash(password, &Params{
+		Memory: 32 * 1024, Iterations: 1, Parallelism: 2,
+		SaltLength: 16, KeyLength: 32, Mode: ModeArgon2id,
+		Pepper: newPepper, PepperID: 1,
+	})
+	if err != nil {
+		t.Fatalf("KeyedHash failed: %v", err)
+	}
+
+	active := [][]byte{oldPepper, newPepper}
+
+	match, err := KeyedVerify(password, oldHash, nil, active)
+	if err != nil || !match {
+		t.Errorf("expected old hash to verify against rotated pepper set, match=%v err=%v", match, err)
+	}
+	match, err = KeyedVerify(password, newHash, nil, active)
+	if err != nil || !match {
+		t.Errorf("expected new hash to verify against rotated pepper set, match=%v err=%v", match, err)
+	}
+}
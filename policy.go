@@ -0,0 +1,111 @@
+package argon
+
+// Policy describes the minimum acceptable Argon2 parameters for a stored
+// hash. It is used by NeedsRehash and VerifyAndRehash to detect hashes
+// that were created under weaker settings than are currently desired,
+// for example after DefaultParams or an operator's configuration changes.
+type Policy struct {
+	// Memory is the minimum acceptable memory (in kibibytes).
+	Memory uint32
+	// Iterations is the minimum acceptable number of passes over the memory.
+	Iterations uint32
+	// Parallelism is the minimum acceptable number of threads (or lanes).
+	Parallelism uint8
+	// KeyLength is the minimum acceptable length of the generated key.
+	KeyLength uint32
+	// SaltLength is the minimum acceptable length of the random salt.
+	SaltLength uint32
+	// Mode is the required variant, expressed as a PHC mode string (e.g.
+	// ModeArgon2id). A stored hash using any other variant is considered
+	// deprecated. If empty, the variant is not checked.
+	Mode string
+}
+
+// policyFromParams derives a Policy that exactly matches target, so that
+// any hash weaker than target (in any dimension) is flagged as needing a
+// rehash.
+func policyFromParams(target *Params) Policy {
+	mode := target.Mode
+	if mode == "" {
+		mode = target.Variant.String()
+	}
+	return Policy{
+		Memory:      target.Memory,
+		Iterations:  target.Iterations,
+		Parallelism: target.Parallelism,
+		KeyLength:   target.KeyLength,
+		SaltLength:  target.SaltLength,
+		Mode:        mode,
+	}
+}
+
+// NeedsRehash reports whether encodedHash was produced with parameters
+// weaker than target, or with a deprecated variant, and should therefore be
+// replaced with a fresh hash the next time the password is verified. This
+// is the standard "upgrade on login" pattern: verify against the stored
+// hash as usual, and if NeedsRehash also returns true, hash the same
+// password again with target and persist the new value.
+func NeedsRehash(encodedHash string, target *Params) (bool, error) {
+	p, _, _, err := decodeHash(encodedHash)
+	if err != nil {
+		return false, err
+	}
+	return policyFromParams(target).outdated(p), nil
+}
+
+// outdated reports whether p falls short of the policy in any dimension.
+func (policy Policy) outdated(p *Params) bool {
+	if p.Memory < policy.Memory {
+		return true
+	}
+	if p.Iterations < policy.Iterations {
+		return true
+	}
+	if p.Parallelism < policy.Parallelism {
+		return true
+	}
+	if p.KeyLength < policy.KeyLength {
+		return true
+	}
+	if p.SaltLength < policy.SaltLength {
+		return true
+	}
+	if policy.Mode != "" && p.Mode != policy.Mode {
+		return true
+	}
+	return false
+}
+
+// VerifyAndRehash verifies password against encodedHash and, if it
+// matches, transparently reports whether the stored hash is outdated
+// relative to target and, if so, produces a fresh replacement. Callers
+// should persist newHash in place of encodedHash whenever it is non-empty.
+//
+//	match, newHash, err := argon.VerifyAndRehash(password, storedHash, argon.DefaultParams())
+//	if err != nil { ... }
+//	if match && newHash != "" {
+//	    // persist newHash, replacing storedHash
+//	}
+func VerifyAndRehash(password, encodedHash string, target *Params) (match bool, newHash string, err error) {
+	match, err = Verify(password, encodedHash)
+	if err != nil {
+		return false, "", err
+	}
+	if !match {
+		return false, "", nil
+	}
+
+	needsRehash, err := NeedsRehash(encodedHash, target)
+	if err != nil {
+		return true, "", err
+	}
+	if !needsRehash {
+		return true, "", nil
+	}
+
+	newHash, err = HashWithParams(password, target)
+	if err != nil {
+		return true, "", err
+	}
+	return true, newHash, nil
+}
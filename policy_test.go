@@ -0,0 +1,159 @@
+package argon
+
+import "testing"
+
+func TestNeedsRehashUpToDate(t *testing.T) {
+	target := &Params{
+		Memory:      32 * 1024,
+		Iterations:  2,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+	}
+
+	hash, err := HashWithParams("password", target)
+	if err != nil {
+		t.Fatalf("HashWithParams failed: %v", err)
+	}
+
+	needsRehash, err := NeedsRehash(hash, target)
+	if err != nil {
+		t.Fatalf("NeedsRehash failed: %v", err)
+	}
+	if needsRehash {
+		t.Error("NeedsRehash returned true for a hash matching the target policy")
+	}
+}
+
+func TestNeedsRehashWeakerParams(t *testing.T) {
+	old := &Params{
+		Memory:      16 * 1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+	}
+	target := &Params{
+		Memory:      32 * 1024,
+		Iterations:  2,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+	}
+
+	hash, err := HashWithParams("password", old)
+	if err != nil {
+		t.Fatalf("HashWithParams failed: %v", err)
+	}
+
+	needsRehash, err := NeedsRehash(hash, target)
+	if err != nil {
+		t.Fatalf("NeedsRehash failed: %v", err)
+	}
+	if !needsRehash {
+		t.Error("NeedsRehash returned false for a hash weaker than the target policy")
+	}
+}
+
+func TestNeedsRehashDeprecatedVariant(t *testing.T) {
+	old := &Params{
+		Memory:      32 * 1024,
+		Iterations:  2,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2i,
+	}
+	target := &Params{
+		Memory:      32 * 1024,
+		Iterations:  2,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+	}
+
+	hash, err := HashWithParams("password", old)
+	if err != nil {
+		t.Fatalf("HashWithParams failed: %v", err)
+	}
+
+	needsRehash, err := NeedsRehash(hash, target)
+	if err != nil {
+		t.Fatalf("NeedsRehash failed: %v", err)
+	}
+	if !needsRehash {
+		t.Error("NeedsRehash returned false for a hash using a deprecated variant")
+	}
+}
+
+func TestVerifyAndRehash(t *testing.T) {
+	password := "correct_horse_battery_staple"
+	old := &Params{
+		Memory:      16 * 1024,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+	}
+	target := &Params{
+		Memory:      32 * 1024,
+		Iterations:  2,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+	}
+
+	hash, err := HashWithParams(password, old)
+	if err != nil {
+		t.Fatalf("HashWithParams failed: %v", err)
+	}
+
+	match, newHash, err := VerifyAndRehash(password, hash, target)
+	if err != nil {
+		t.Fatalf("VerifyAndRehash failed: %v", err)
+	}
+	if !match {
+		t.Fatal("VerifyAndRehash returned false for correct password")
+	}
+	if newHash == "" {
+		t.Fatal("VerifyAndRehash did not return a new hash for an outdated stored hash")
+	}
+
+	match, err = Verify(password, newHash)
+	if err != nil {
+		t.Fatalf("Verify of rehashed value failed: %v", err)
+	}
+	if !match {
+		t.Error("newHash returned by VerifyAndRehash does not verify against the original password")
+	}
+
+	// Rehashing again against the same target should be a no-op.
+	match, newHash, err = VerifyAndRehash(password, newHash, target)
+	if err != nil {
+		t.Fatalf("VerifyAndRehash failed: %v", err)
+	}
+	if !match || newHash != "" {
+		t.Error("VerifyAndRehash should not rehash a hash already meeting the target policy")
+	}
+}
+
+func TestVerifyAndRehashWrongPassword(t *testing.T) {
+	hash, err := Hash("correct_horse_battery_staple")
+	if err != nil {
+		t.Fatalf("Hash failed: %v", err)
+	}
+
+	match, newHash, err := VerifyAndRehash("wrong_password", hash, DefaultParams())
+	if err != nil {
+		t.Fatalf("VerifyAndRehash failed: %v", err)
+	}
+	if match || newHash != "" {
+		t.Error("VerifyAndRehash should not report a match or return a new hash for a wrong password")
+	}
+}
@@ -0,0 +1,107 @@
+package argon
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// ErrHasherClosed is returned by a Hasher's methods once it has been closed.
+var ErrHasherClosed = errors.New("argon: hasher is closed")
+
+// Hasher accumulates a secret written to it in chunks and derives an
+// Argon2 key from the full secret on demand via Sum or Raw. Argon2 itself
+// is not an incremental algorithm, so Hasher exists to spare callers the
+// need to buffer passphrases or key material (from a file, a network
+// stream, or an HSM) themselves before hashing. The accumulated bytes are
+// held in a locked buffer where the platform supports it and are zeroed
+// by Close.
+//
+// p.KeyLength may be set to any length, which turns Hasher into a
+// general-purpose Argon2 KDF rather than a fixed-size password hasher.
+type Hasher struct {
+	params *Params
+	salt   []byte
+	buf    lockedBuffer
+	closed bool
+}
+
+// NewHasher returns a Hasher configured with p. The random salt is
+// generated immediately so that Sum and Raw, called any number of times,
+// derive consistently from the same salt.
+func NewHasher(p *Params) (*Hasher, error) {
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	buf, err := newLockedBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return &Hasher{params: p, salt: salt, buf: buf}, nil
+}
+
+var _ io.Writer = (*Hasher)(nil)
+
+// Write appends p to the accumulated secret. It satisfies io.Writer.
+func (h *Hasher) Write(p []byte) (int, error) {
+	if h.closed {
+		return 0, ErrHasherClosed
+	}
+	if err := h.buf.write(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Sum derives the Argon2 key for the secret written so far and returns it
+// as a matchable PHC string, in the same format as HashWithParams.
+func (h *Hasher) Sum() (string, error) {
+	if h.closed {
+		return "", ErrHasherClosed
+	}
+	variant, err := h.params.variant()
+	if err != nil {
+		return "", err
+	}
+
+	hash := h.derive(variant)
+	b64Salt := base64.RawStdEncoding.EncodeToString(h.salt)
+	b64Hash := base64.RawStdEncoding.EncodeToString(hash)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		variant, argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism, b64Salt, b64Hash), nil
+}
+
+// Raw derives and returns the raw Argon2 key material for the secret
+// written so far, without PHC encoding. Use this for KDF use cases where
+// the caller wants key bytes rather than a matchable password hash.
+func (h *Hasher) Raw() ([]byte, error) {
+	if h.closed {
+		return nil, ErrHasherClosed
+	}
+	variant, err := h.params.variant()
+	if err != nil {
+		return nil, err
+	}
+	return h.derive(variant), nil
+}
+
+func (h *Hasher) derive(variant Variant) []byte {
+	return deriveKey(variant, h.buf.bytes(), h.salt, nil, h.params.AssociatedData,
+		h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+}
+
+// Close zeroes the accumulated secret and releases its locked buffer, if
+// any. A closed Hasher can no longer be written to or summed.
+func (h *Hasher) Close() error {
+	if h.closed {
+		return nil
+	}
+	h.closed = true
+	return h.buf.close()
+}
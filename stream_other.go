@@ -0,0 +1,32 @@
+//go:build !unix
+
+package argon
+
+// lockedBuffer is the non-unix fallback: it accumulates bytes in a plain
+// slice, without memory locking, since there is no portable mlock on
+// these platforms. The buffer is still zeroed on close.
+type lockedBuffer struct {
+	data []byte
+}
+
+func newLockedBuffer() (lockedBuffer, error) {
+	return lockedBuffer{}, nil
+}
+
+func (b *lockedBuffer) write(p []byte) error {
+	b.data = append(b.data, p...)
+	return nil
+}
+
+func (b *lockedBuffer) bytes() []byte {
+	return b.data
+}
+
+func (b *lockedBuffer) close() error {
+	full := b.data[:cap(b.data)]
+	for i := range full {
+		full[i] = 0
+	}
+	b.data = nil
+	return nil
+}
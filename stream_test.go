@@ -0,0 +1,155 @@
+package argon
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasherWriteChunksAndSum(t *testing.T) {
+	params := &Params{
+		Memory:      32 * 1024,
+		Iterations:  1,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+		Mode:        ModeArgon2id,
+	}
+
+	h, err := NewHasher(params)
+	if err != nil {
+		t.Fatalf("NewHasher failed: %v", err)
+	}
+	defer h.Close()
+
+	secret := "a-passphrase-delivered-in-chunks"
+	for _, chunk := range strings.SplitAfter(secret, "-") {
+		if _, err := h.Write([]byte(chunk)); err != nil {
+			t.Fatalf("Write failed: %v", err)
+		}
+	}
+
+	streamed, err := h.Sum()
+	if err != nil {
+		t.Fatalf("Sum failed: %v", err)
+	}
+
+	whole, err := HashWithParams(secret, params)
+	if err != nil {
+		t.Fatalf("HashWithParams failed: %v", err)
+	}
+
+	// Both hashes used a random salt, so they won't be byte-identical, but
+	// a streamed hash must verify against the whole secret and vice versa.
+	match, err := Verify(secret, streamed)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !match {
+		t.Error("streamed Hasher output does not verify against the equivalent whole-secret hash")
+	}
+
+	match, err = Verify(secret, whole)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if !match {
+		t.Error("whole-secret hash does not verify against itself")
+	}
+}
+
+func TestHasherRawKDF(t *testing.T) {
+	params := &Params{
+		Memory:      32 * 1024,
+		Iterations:  1,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   64,
+		Mode:        ModeArgon2id,
+	}
+
+	h, err := NewHasher(params)
+	if err != nil {
+		t.Fatalf("NewHasher failed: %v", err)
+	}
+	defer h.Close()
+
+	if _, err := h.Write([]byte("key material from an hsm")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	key, err := h.Raw()
+	if err != nil {
+		t.Fatalf("Raw failed: %v", err)
+	}
+	if len(key) != 64 {
+		t.Errorf("expected %d bytes of key material, got %d", 64, len(key))
+	}
+
+	// Raw is deterministic for the same accumulated input and salt.
+	key2, err := h.Raw()
+	if err != nil {
+		t.Fatalf("Raw failed: %v", err)
+	}
+	if string(key) != string(key2) {
+		t.Error("Raw should be deterministic across repeated calls")
+	}
+}
+
+func TestHasherRawOddParallelismWithAssociatedData(t *testing.T) {
+	// Hasher.derive takes the deriveKey/argon2Core path whenever
+	// AssociatedData is set; this runs it with a memory cost that isn't a
+	// multiple of 4*Parallelism and checks the length of the key material.
+	// See TestKeyedHashAndVerifyOddParallelismWithAssociatedData in
+	// pepper_test.go for the same case via KeyedHash.
+	params := &Params{
+		Memory:         64,
+		Iterations:     2,
+		Parallelism:    3,
+		SaltLength:     16,
+		KeyLength:      32,
+		Mode:           ModeArgon2id,
+		AssociatedData: []byte("kdf-context"),
+	}
+
+	h, err := NewHasher(params)
+	if err != nil {
+		t.Fatalf("NewHasher failed: %v", err)
+	}
+	defer h.Close()
+
+	if _, err := h.Write([]byte("key material from an hsm")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	key, err := h.Raw()
+	if err != nil {
+		t.Fatalf("Raw failed: %v", err)
+	}
+	if len(key) != 32 {
+		t.Errorf("expected 32 bytes of key material, got %d", len(key))
+	}
+}
+
+func TestHasherClosed(t *testing.T) {
+	h, err := NewHasher(DefaultParams())
+	if err != nil {
+		t.Fatalf("NewHasher failed: %v", err)
+	}
+
+	if _, err := h.Write([]byte("secret")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, err := h.Write([]byte("more")); err != ErrHasherClosed {
+		t.Errorf("Expected ErrHasherClosed from Write after Close, got %v", err)
+	}
+	if _, err := h.Sum(); err != ErrHasherClosed {
+		t.Errorf("Expected ErrHasherClosed from Sum after Close, got %v", err)
+	}
+	if _, err := h.Raw(); err != ErrHasherClosed {
+		t.Errorf("Expected ErrHasherClosed from Raw after Close, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build unix
+
+package argon
+
+import "golang.org/x/sys/unix"
+
+// lockedBuffer accumulates bytes in memory that has been mlock'd to
+// prevent it from being paged to swap, zeroing and munlock'ing it on
+// close. It grows by reallocating and copying into a larger mlock'd
+// region, since the kernel has no "extend this locked mapping" call.
+type lockedBuffer struct {
+	data   []byte
+	locked bool
+}
+
+func newLockedBuffer() (lockedBuffer, error) {
+	return lockedBuffer{}, nil
+}
+
+func (b *lockedBuffer) write(p []byte) error {
+	need := len(b.data) + len(p)
+	if need > cap(b.data) {
+		grown := make([]byte, len(b.data), growCap(cap(b.data), need))
+		copy(grown, b.data)
+
+		wasLocked := b.locked
+		oldData := b.data
+
+		// Locking is a best-effort hardening measure: it can fail under
+		// restrictive ulimits (e.g. in containers), in which case we
+		// still accumulate the secret, just without the swap protection.
+		b.locked = unix.Mlock(grown[:cap(grown)]) == nil
+		b.data = grown
+
+		if wasLocked {
+			unix.Munlock(oldData[:cap(oldData)])
+		}
+		zero(oldData[:cap(oldData)])
+	}
+	b.data = append(b.data, p...)
+	return nil
+}
+
+func (b *lockedBuffer) bytes() []byte {
+	return b.data
+}
+
+func (b *lockedBuffer) close() error {
+	full := b.data[:cap(b.data)]
+	zero(full)
+	if b.locked {
+		defer func() { b.locked = false }()
+		return unix.Munlock(full)
+	}
+	return nil
+}
+
+func zero(p []byte) {
+	for i := range p {
+		p[i] = 0
+	}
+}
+
+func growCap(current, need int) int {
+	if current == 0 {
+		current = 4096
+	}
+	for current < need {
+		current *= 2
+	}
+	return current
+}